@@ -1,11 +1,17 @@
 package i2c
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"sort"
 	"time"
+
+	"gobot.io/x/gobot/v2"
+	"gobot.io/x/gobot/v2/drivers/gpio"
 )
 
 const (
@@ -38,14 +44,81 @@ const (
 	qmc5883lCtrl2_SOFT_RST = 0x80 // Soft reset
 	qmc5883lCtrl2_ROL_PNT  = 0x40 // Roll-over function
 	qmc5883lCtrl2_INT_ENB  = 0x01 // Interrupt Pin Enabling
+
+	qmc5883lStatus_DRDY = 0x01 // Data Ready bit in the Status Register
+)
+
+// Scale factors, in LSB per Gauss, for each supported range. Exposed so
+// downstream code can convert raw counts (see ReadRaw) itself.
+const (
+	QMC5883LScale2G = 12000.0 // LSB/Gauss for the ±2G range
+	QMC5883LScale8G = 3000.0  // LSB/Gauss for the ±8G range
+)
+
+// QMC5883LCalibration holds the hard-iron offset and soft-iron scale correction
+// used to turn raw magnetometer samples into corrected readings:
+//
+//	corrected = soft * (raw - hardOffset)
+type QMC5883LCalibration struct {
+	HardOffset [3]float64    `json:"hard_offset"`
+	SoftScale  [3][3]float64 `json:"soft_scale"`
+}
+
+// identityQMC5883LCalibration is the no-op calibration applied before the user
+// runs Calibrate() or loads a saved calibration.
+func identityQMC5883LCalibration() QMC5883LCalibration {
+	return QMC5883LCalibration{
+		SoftScale: [3][3]float64{
+			{1, 0, 0},
+			{0, 1, 0},
+			{0, 0, 1},
+		},
+	}
+}
+
+// AccelerometerSource lets a QMC5883LDriver pull the current tilt of the
+// device from any gobot accelerometer driver (MPU6050, ADXL345, etc.) so
+// Heading() can correct for a sensor that isn't mounted level.
+type AccelerometerSource interface {
+	Tilt() (pitch, roll float64, err error)
+}
+
+// Reading is a single, calibrated magnetometer sample published by Start().
+// If Err is non-nil, sampling failed and the stream ends after this Reading
+// is delivered; the other fields should be ignored.
+type Reading struct {
+	X, Y, Z   float64
+	Heading   float64
+	Timestamp time.Time
+	Err       error
+}
+
+const (
+	// QMC5883LMagnetometerEvent is published on every Reading produced while streaming.
+	QMC5883LMagnetometerEvent = "magnetometer"
+	// QMC5883LHeadingEvent is published with the heading in degrees of every Reading produced while streaming.
+	QMC5883LHeadingEvent = "heading"
 )
 
 type QMC5883LDriver struct {
 	*Driver
-	odr  uint8
-	rng  uint8
-	osr  uint8
-	mode uint8
+	gobot.Eventer
+	odr         uint8
+	rng         uint8
+	osr         uint8
+	mode        uint8
+	calibration QMC5883LCalibration
+	accel       AccelerometerSource
+	declination float64
+	drdyAdaptor gpio.DigitalReader
+	drdyPin     string
+
+	numReadings int
+	sampleDelay time.Duration
+
+	lowPassAlpha float64
+	lowPassState [3]float64
+	lowPassInit  bool
 }
 
 var qmc5883lODRBits = map[uint8]uint8{
@@ -69,11 +142,15 @@ var qmc5883lOSRBits = map[uint16]uint8{
 
 func NewQMC5883LDriver(c Connector, options ...func(Config)) *QMC5883LDriver {
 	q := &QMC5883LDriver{
-		Driver: NewDriver(c, "QMC5883L", qmc5883lDefaultAddress),
-		odr:    50,
-		rng:    2,
-		osr:    qmc5883lOSRBits[512],
-		mode:   qmc5883lCtrl1_Mode_Continuous,
+		Driver:      NewDriver(c, "QMC5883L", qmc5883lDefaultAddress),
+		Eventer:     gobot.NewEventer(),
+		odr:         50,
+		rng:         2,
+		osr:         qmc5883lOSRBits[512],
+		mode:        qmc5883lCtrl1_Mode_Continuous,
+		calibration: identityQMC5883LCalibration(),
+		numReadings: 10,
+		sampleDelay: 10 * time.Millisecond,
 	}
 	q.afterStart = q.initialize
 
@@ -126,14 +203,117 @@ func WithQMC5883LOSR(val uint16) func(Config) {
 	}
 }
 
+// WithQMC5883LAccelerometer wires in an accelerometer driver so Heading() can
+// tilt-compensate automatically instead of assuming the sensor is level.
+func WithQMC5883LAccelerometer(src AccelerometerSource) func(Config) {
+	return func(c Config) {
+		d, ok := c.(*QMC5883LDriver)
+		if ok {
+			d.accel = src
+		} else if qmc5883lDebug {
+			log.Printf("Trying to set accelerometer source for non-QMC5883LDriver %v", c)
+		}
+	}
+}
+
+// WithQMC5883LDeclination sets a fixed magnetic declination (in degrees) that
+// is added to every heading so it reports true north instead of magnetic
+// north.
+func WithQMC5883LDeclination(deg float64) func(Config) {
+	return func(c Config) {
+		d, ok := c.(*QMC5883LDriver)
+		if ok {
+			d.declination = deg
+		} else if qmc5883lDebug {
+			log.Printf("Trying to set declination for non-QMC5883LDriver %v", c)
+		}
+	}
+}
+
+// WithQMC5883LDataReadyPin drives streaming reads from the DRDY interrupt
+// signal on the given GPIO pin instead of polling the status register. The
+// pin's level is polled every millisecond and a sample is read when it is
+// high; edges are not detected. The QMC5883L must have its INT pin wired to
+// the adaptor's pin for this to fire.
+func WithQMC5883LDataReadyPin(adaptor gpio.DigitalReader, pin string) func(Config) {
+	return func(c Config) {
+		d, ok := c.(*QMC5883LDriver)
+		if ok {
+			d.drdyAdaptor = adaptor
+			d.drdyPin = pin
+		} else if qmc5883lDebug {
+			log.Printf("Trying to set data-ready pin for non-QMC5883LDriver %v", c)
+		}
+	}
+}
+
+// WithQMC5883LAveraging sets how many raw samples Read, Heading and
+// TiltCompensatedHeading average together to reduce noise; Start does not
+// average. Higher values trade latency (n *
+// WithQMC5883LSampleDelay) for a smoother signal; pass 1 to disable averaging.
+func WithQMC5883LAveraging(n int) func(Config) {
+	return func(c Config) {
+		d, ok := c.(*QMC5883LDriver)
+		if ok {
+			if n < 1 {
+				panic(fmt.Errorf("averaging must be at least 1, got %d", n))
+			}
+			d.numReadings = n
+		} else if qmc5883lDebug {
+			log.Printf("Trying to set averaging for non-QMC5883LDriver %v", c)
+		}
+	}
+}
+
+// WithQMC5883LSampleDelay sets the delay between the raw samples averaged
+// together by Read, Heading and TiltCompensatedHeading. Defaults to 10ms; pass 0 to read back
+// to back as fast as the bus allows.
+func WithQMC5883LSampleDelay(d time.Duration) func(Config) {
+	return func(c Config) {
+		q, ok := c.(*QMC5883LDriver)
+		if ok {
+			q.sampleDelay = d
+		} else if qmc5883lDebug {
+			log.Printf("Trying to set sample delay for non-QMC5883LDriver %v", c)
+		}
+	}
+}
+
+// WithQMC5883LLowPass enables a per-axis IIR low-pass filter
+// (y[n] = alpha*x[n] + (1-alpha)*y[n-1]) applied after averaging and
+// calibration, so callers running at a high ODR can get smooth output
+// without relying on blocking sleeps in the averaging window. alpha must be
+// in (0, 1]; smaller values smooth more but react more slowly.
+func WithQMC5883LLowPass(alpha float64) func(Config) {
+	return func(c Config) {
+		d, ok := c.(*QMC5883LDriver)
+		if ok {
+			if alpha <= 0 || alpha > 1 {
+				panic(fmt.Errorf("low-pass alpha must be in (0, 1], got %v", alpha))
+			}
+			d.lowPassAlpha = alpha
+		} else if qmc5883lDebug {
+			log.Printf("Trying to set low-pass alpha for non-QMC5883LDriver %v", c)
+		}
+	}
+}
+
+// Read returns a single calibrated magnetometer sample, averaged over
+// q.numReadings raw reads. It's a convenience for callers that only need an
+// occasional reading rather than the continuous updates Start provides.
 func (q *QMC5883LDriver) Read() (x float64, y float64, z float64, err error) {
+	return q.sample()
+}
+
+// sample averages q.numReadings raw samples, scales them to gauss, applies
+// the current calibration and, if configured, the low-pass filter.
+func (q *QMC5883LDriver) sample() (x float64, y float64, z float64, err error) {
 	q.mutex.Lock()
 	defer q.mutex.Unlock()
 
-	const numReadings = 10 // Number of readings to average
 	var sumX, sumY, sumZ int32
 
-	for i := 0; i < numReadings; i++ {
+	for i := 0; i < q.numReadings; i++ {
 		xr, yr, zr, err := q.readRawData()
 		if err != nil {
 			return 0, 0, 0, err
@@ -141,38 +321,422 @@ func (q *QMC5883LDriver) Read() (x float64, y float64, z float64, err error) {
 		sumX += int32(xr)
 		sumY += int32(yr)
 		sumZ += int32(zr)
-		time.Sleep(10 * time.Millisecond) // Short delay between readings
+		if q.sampleDelay > 0 {
+			time.Sleep(q.sampleDelay) // Short delay between readings
+		}
 	}
 
-	avgX := float64(sumX) / numReadings
-	avgY := float64(sumY) / numReadings
-	avgZ := float64(sumZ) / numReadings
+	avgX := float64(sumX) / float64(q.numReadings)
+	avgY := float64(sumY) / float64(q.numReadings)
+	avgZ := float64(sumZ) / float64(q.numReadings)
+
+	x, y, z = q.applyCalibration(avgX/q.scale(), avgY/q.scale(), avgZ/q.scale())
 
-	scale := 12000.0 // for 2G range
+	if q.lowPassAlpha > 0 {
+		x, y, z = q.applyLowPass(x, y, z)
+	}
+
+	return x, y, z, nil
+}
+
+// scale returns the LSB/Gauss factor for the configured range.
+func (q *QMC5883LDriver) scale() float64 {
 	if q.rng == 8 {
-		scale = 3000.0 // for 8G range
+		return QMC5883LScale8G
+	}
+	return QMC5883LScale2G
+}
+
+// applyLowPass runs x, y, z through the per-axis IIR low-pass filter enabled
+// by WithQMC5883LLowPass, seeding the filter state on the first call.
+func (q *QMC5883LDriver) applyLowPass(x, y, z float64) (float64, float64, float64) {
+	if !q.lowPassInit {
+		q.lowPassState = [3]float64{x, y, z}
+		q.lowPassInit = true
+		return x, y, z
+	}
+
+	sample := [3]float64{x, y, z}
+	for i, v := range sample {
+		q.lowPassState[i] = q.lowPassAlpha*v + (1-q.lowPassAlpha)*q.lowPassState[i]
 	}
 
-	return avgX / scale, avgY / scale, avgZ / scale, nil
+	return q.lowPassState[0], q.lowPassState[1], q.lowPassState[2]
 }
 
+// sampleOnce reads a single raw sample, scales it to gauss, applies the
+// current calibration and, if configured, the low-pass filter. Unlike
+// sample, it does not average multiple raw reads, so Start can pair it
+// directly with a per-Reading DRDY wait instead of burning through several
+// stale register reads for every published Reading.
+func (q *QMC5883LDriver) sampleOnce() (x float64, y float64, z float64, err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	xr, yr, zr, err := q.readRawData()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	x, y, z = q.applyCalibration(float64(xr)/q.scale(), float64(yr)/q.scale(), float64(zr)/q.scale())
+
+	if q.lowPassAlpha > 0 {
+		x, y, z = q.applyLowPass(x, y, z)
+	}
+
+	return x, y, z, nil
+}
+
+// ReadRaw returns a single, un-averaged, un-calibrated raw sample straight
+// off the sensor, for callers doing their own fusion or calibration.
+func (q *QMC5883LDriver) ReadRaw() (x int16, y int16, z int16, err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.readRawData()
+}
+
+// ReadScaled returns a single calibrated sample, scaled to gauss, without
+// averaging or low-pass filtering — useful for fusion algorithms that want
+// to do their own smoothing.
+func (q *QMC5883LDriver) ReadScaled() (x float64, y float64, z float64, err error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	xr, yr, zr, err := q.readRawData()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	x, y, z = q.applyCalibration(float64(xr)/q.scale(), float64(yr)/q.scale(), float64(zr)/q.scale())
+
+	return x, y, z, nil
+}
+
+// Start begins continuous sampling at the configured ODR and returns a
+// channel of calibrated Readings, also published as the "magnetometer" and
+// "heading" events. Each Reading is one DRDY-triggered raw sample (not the
+// multi-sample average Read uses) so the stream actually runs at the
+// configured ODR instead of stalling behind an averaging window; callers
+// that want a smoother signal at a high ODR should configure
+// WithQMC5883LLowPass rather than WithQMC5883LAveraging. A sample is
+// triggered by a high level on the DRDY interrupt pin when
+// WithQMC5883LDataReadyPin is configured, otherwise by polling the DRDY bit in
+// the status register. If a read fails, the failure is delivered as a Reading
+// with Err set and the channel is then closed; the channel is also closed once
+// ctx is done.
+func (q *QMC5883LDriver) Start(ctx context.Context) (<-chan Reading, error) {
+	out := make(chan Reading, 16)
+	interval := time.Second / time.Duration(q.odr)
+
+	go func() {
+		defer close(out)
+
+		for {
+			if err := q.waitForDataReady(ctx, interval); err != nil {
+				if ctx.Err() == nil {
+					q.publishStreamError(ctx, out, err)
+				}
+				return
+			}
+
+			x, y, z, err := q.sampleOnce()
+			if err != nil {
+				q.publishStreamError(ctx, out, err)
+				return
+			}
+
+			heading, err := q.headingFromSample(x, y, z)
+			if err != nil {
+				q.publishStreamError(ctx, out, err)
+				return
+			}
+
+			reading := Reading{X: x, Y: y, Z: z, Heading: heading, Timestamp: time.Now()}
+
+			q.Publish(QMC5883LMagnetometerEvent, reading)
+			q.Publish(QMC5883LHeadingEvent, heading)
+
+			select {
+			case out <- reading:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// publishStreamError delivers a failed sample's error as a final Reading
+// before Start's goroutine closes the channel, so callers see the real
+// failure instead of just a closed channel.
+func (q *QMC5883LDriver) publishStreamError(ctx context.Context, out chan<- Reading, err error) {
+	if qmc5883lDebug {
+		log.Printf("qmc5883l: stream sample failed: %v", err)
+	}
+
+	select {
+	case out <- Reading{Err: err}:
+	case <-ctx.Done():
+	}
+}
+
+// waitForDataReady blocks until a new sample is available, either via the
+// configured DRDY GPIO pin or by polling the status register every
+// pollInterval, and returns ctx.Err() once ctx is done.
+func (q *QMC5883LDriver) waitForDataReady(ctx context.Context, pollInterval time.Duration) error {
+	if q.drdyAdaptor != nil {
+		return q.waitForDataReadyPin(ctx)
+	}
+	return q.waitForDataReadyPoll(ctx, pollInterval)
+}
+
+func (q *QMC5883LDriver) waitForDataReadyPin(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		val, err := q.drdyAdaptor.DigitalRead(q.drdyPin)
+		if err != nil {
+			return err
+		}
+		if val == 1 {
+			return nil
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (q *QMC5883LDriver) waitForDataReadyPoll(ctx context.Context, pollInterval time.Duration) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		status, err := q.connection.ReadByteData(qmc5883lRegStatus)
+		if err != nil {
+			return err
+		}
+		if status&qmc5883lStatus_DRDY != 0 {
+			return nil
+		}
+	}
+}
+
+// applyCalibration corrects a raw scaled sample using the hard-iron offset and
+// soft-iron scale matrix: corrected = soft * (raw - hardOffset).
+func (q *QMC5883LDriver) applyCalibration(x, y, z float64) (float64, float64, float64) {
+	raw := [3]float64{x - q.calibration.HardOffset[0], y - q.calibration.HardOffset[1], z - q.calibration.HardOffset[2]}
+	soft := q.calibration.SoftScale
+
+	return soft[0][0]*raw[0] + soft[0][1]*raw[1] + soft[0][2]*raw[2],
+		soft[1][0]*raw[0] + soft[1][1]*raw[1] + soft[1][2]*raw[2],
+		soft[2][0]*raw[0] + soft[2][1]*raw[1] + soft[2][2]*raw[2]
+}
+
+// Calibrate collects samples, scaled to gauss like the readings the
+// calibration is applied to, for the given duration while the device is
+// rotated through as many orientations as possible, then derives a hard-iron
+// offset and an axis-aligned soft-iron scale matrix from the observed
+// min/max on each axis. If ctx is cancelled before duration elapses,
+// Calibrate returns ctx.Err() without committing a calibration, leaving any
+// previously installed calibration in place.
+//
+// The hard-iron offset is the midpoint of the min/max on each axis. The
+// soft-iron scale normalizes each axis to the average radius of the three
+// axes, i.e. a basic ellipsoid-to-sphere correction. Callers that need a full
+// 3x3 least-squares ellipsoid fit can compute their own SoftScale and install
+// it with SetCalibration.
+func (q *QMC5883LDriver) Calibrate(ctx context.Context, duration time.Duration) error {
+	deadline := time.After(duration)
+
+	min := [3]float64{math.MaxFloat64, math.MaxFloat64, math.MaxFloat64}
+	max := [3]float64{-math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64}
+
+	for {
+		xr, yr, zr, err := q.ReadRaw()
+		if err != nil {
+			return err
+		}
+
+		scale := q.scale()
+		sample := [3]float64{float64(xr) / scale, float64(yr) / scale, float64(zr) / scale}
+		for i, v := range sample {
+			if v < min[i] {
+				min[i] = v
+			}
+			if v > max[i] {
+				max[i] = v
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			// Cancelled mid-rotation: the collected min/max is likely
+			// incomplete, so leave any existing calibration in place
+			// rather than committing a bad one.
+			return ctx.Err()
+		case <-deadline:
+			return q.finishCalibration(min, max)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (q *QMC5883LDriver) finishCalibration(min, max [3]float64) error {
+	calibration := deriveQMC5883LCalibration(min, max)
+
+	q.mutex.Lock()
+	q.calibration = calibration
+	q.mutex.Unlock()
+
+	return nil
+}
+
+// deriveQMC5883LCalibration turns the observed per-axis min/max values
+// into a hard-iron offset (the midpoint of min/max) and an axis-aligned
+// soft-iron scale matrix that normalizes each axis to the average radius of
+// the three axes.
+func deriveQMC5883LCalibration(min, max [3]float64) QMC5883LCalibration {
+	var hardOffset [3]float64
+	var radius [3]float64
+
+	for i := 0; i < 3; i++ {
+		hardOffset[i] = (max[i] + min[i]) / 2
+		radius[i] = (max[i] - min[i]) / 2
+	}
+
+	avgRadius := (radius[0] + radius[1] + radius[2]) / 3
+
+	var softScale [3][3]float64
+	for i := 0; i < 3; i++ {
+		if radius[i] == 0 {
+			softScale[i][i] = 1
+			continue
+		}
+		softScale[i][i] = avgRadius / radius[i]
+	}
+
+	return QMC5883LCalibration{HardOffset: hardOffset, SoftScale: softScale}
+}
+
+// SetCalibration installs a hard-iron offset and soft-iron scale matrix
+// computed elsewhere (e.g. a full least-squares ellipsoid fit) in place of
+// whatever Calibrate produced.
+func (q *QMC5883LDriver) SetCalibration(hard [3]float64, soft [3][3]float64) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.calibration = QMC5883LCalibration{HardOffset: hard, SoftScale: soft}
+}
+
+// GetCalibration returns the hard-iron offset and soft-iron scale matrix
+// currently applied to readings.
+func (q *QMC5883LDriver) GetCalibration() (hard [3]float64, soft [3][3]float64) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return q.calibration.HardOffset, q.calibration.SoftScale
+}
+
+// SaveCalibration writes the current calibration as JSON so it can be
+// restored with LoadCalibration after a restart.
+func (q *QMC5883LDriver) SaveCalibration(w io.Writer) error {
+	q.mutex.Lock()
+	calibration := q.calibration
+	q.mutex.Unlock()
+
+	return json.NewEncoder(w).Encode(calibration)
+}
+
+// LoadCalibration reads a calibration previously written by SaveCalibration
+// and installs it.
+func (q *QMC5883LDriver) LoadCalibration(r io.Reader) error {
+	var calibration QMC5883LCalibration
+	if err := json.NewDecoder(r).Decode(&calibration); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	q.calibration = calibration
+	q.mutex.Unlock()
+
+	return nil
+}
+
+// Heading returns the compass heading in degrees, normalized to 0-360. If an
+// accelerometer source has been configured via WithQMC5883LAccelerometer, the
+// heading is automatically tilt-compensated using its current pitch and roll.
+// Any configured declination (WithQMC5883LDeclination) is added so the result
+// is true-north rather than magnetic-north.
 func (q *QMC5883LDriver) Heading() (float64, error) {
-	x, y, _, err := q.Read() // Using filtered Read
+	x, y, z, err := q.sample()
+	if err != nil {
+		return 0, err
+	}
+
+	return q.headingFromSample(x, y, z)
+}
+
+// TiltCompensatedHeading returns the compass heading in degrees corrected for
+// a sensor that is pitched and/or rolled away from level, given the pitch and
+// roll in radians. Any configured declination is added to the result.
+func (q *QMC5883LDriver) TiltCompensatedHeading(pitch, roll float64) (float64, error) {
+	x, y, z, err := q.sample()
 	if err != nil {
 		return 0, err
 	}
 
-	heading := math.Atan2(y, x)
+	return q.tiltCompensatedHeadingFromSample(x, y, z, pitch, roll), nil
+}
 
-	// Convert to degrees
-	headingDegrees := heading * 180 / math.Pi
+// headingFromSample computes the heading for an already-read sample,
+// automatically tilt-compensating when an accelerometer source is configured.
+func (q *QMC5883LDriver) headingFromSample(x, y, z float64) (float64, error) {
+	if q.accel != nil {
+		pitch, roll, err := q.accel.Tilt()
+		if err != nil {
+			return 0, err
+		}
+		return q.tiltCompensatedHeadingFromSample(x, y, z, pitch, roll), nil
+	}
+
+	return q.normalizeHeading(math.Atan2(y, x)), nil
+}
 
-	// Normalize to 0-360
+func (q *QMC5883LDriver) tiltCompensatedHeadingFromSample(x, y, z, pitch, roll float64) float64 {
+	cosPitch := math.Cos(pitch)
+	sinPitch := math.Sin(pitch)
+	cosRoll := math.Cos(roll)
+	sinRoll := math.Sin(roll)
+
+	xh := x*cosPitch + z*sinPitch
+	yh := x*sinRoll*sinPitch + y*cosRoll - z*sinRoll*cosPitch
+
+	return q.normalizeHeading(math.Atan2(-yh, xh))
+}
+
+// normalizeHeading converts radians to a 0-360 degree heading, adding the
+// configured declination.
+func (q *QMC5883LDriver) normalizeHeading(radians float64) float64 {
+	headingDegrees := radians*180/math.Pi + q.declination
+
+	headingDegrees = math.Mod(headingDegrees, 360)
 	if headingDegrees < 0 {
 		headingDegrees += 360
 	}
 
-	return headingDegrees, nil
+	return headingDegrees
 }
 
 func (q *QMC5883LDriver) readRawData() (x int16, y int16, z int16, err error) {
@@ -205,6 +769,9 @@ func (q *QMC5883LDriver) initialize() error {
 
 	// Configure Control Register 2 (optional, adjust as needed)
 	ctrl2 := uint8(qmc5883lCtrl2_ROL_PNT) // Enable pointer roll-over
+	if q.drdyAdaptor != nil {
+		ctrl2 |= qmc5883lCtrl2_INT_ENB // Drive the INT pin so DRDY can be read from a GPIO pin
+	}
 	return q.connection.WriteByteData(qmc5883lRegCtrl2, ctrl2)
 }
 
@@ -0,0 +1,183 @@
+package i2c
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func floatsClose(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// qmc5883lTestConnection is a no-op Connection used to construct a
+// QMC5883LDriver for tests that don't talk to the bus.
+type qmc5883lTestConnection struct{}
+
+func (c *qmc5883lTestConnection) ReadByteData(reg uint8) (uint8, error)      { return 0, nil }
+func (c *qmc5883lTestConnection) WriteByteData(reg uint8, val uint8) error   { return nil }
+func (c *qmc5883lTestConnection) ReadWordData(reg uint8) (uint16, error)     { return 0, nil }
+func (c *qmc5883lTestConnection) WriteWordData(reg uint8, val uint16) error  { return nil }
+func (c *qmc5883lTestConnection) ReadBlockData(reg uint8, data []byte) error { return nil }
+func (c *qmc5883lTestConnection) WriteBlockData(reg uint8, data []byte) error {
+	return nil
+}
+func (c *qmc5883lTestConnection) Read(p []byte) (int, error)  { return 0, nil }
+func (c *qmc5883lTestConnection) Write(p []byte) (int, error) { return 0, nil }
+func (c *qmc5883lTestConnection) Close() error                { return nil }
+
+type qmc5883lTestConnector struct{}
+
+func (c *qmc5883lTestConnector) GetConnection(address int, bus int) (Connection, error) {
+	return &qmc5883lTestConnection{}, nil
+}
+func (c *qmc5883lTestConnector) GetDefaultBus() int     { return 0 }
+func (c *qmc5883lTestConnector) GetDefaultAddress() int { return qmc5883lDefaultAddress }
+
+func newTestQMC5883LDriver() *QMC5883LDriver {
+	return NewQMC5883LDriver(&qmc5883lTestConnector{})
+}
+
+func TestQMC5883LApplyCalibration(t *testing.T) {
+	q := &QMC5883LDriver{
+		calibration: QMC5883LCalibration{
+			HardOffset: [3]float64{1, -2, 3},
+			SoftScale: [3][3]float64{
+				{2, 0, 0},
+				{0, 0.5, 0},
+				{0, 0, 1},
+			},
+		},
+	}
+
+	x, y, z := q.applyCalibration(5, 2, -3)
+
+	if !floatsClose(x, 8) { // (5-1)*2
+		t.Errorf("x = %v, want 8", x)
+	}
+	if !floatsClose(y, 2) { // (2-(-2))*0.5
+		t.Errorf("y = %v, want 2", y)
+	}
+	if !floatsClose(z, -6) { // (-3-3)*1
+		t.Errorf("z = %v, want -6", z)
+	}
+}
+
+func TestDeriveQMC5883LCalibration(t *testing.T) {
+	min := [3]float64{-100, -200, -50}
+	max := [3]float64{100, 0, 150}
+
+	got := deriveQMC5883LCalibration(min, max)
+
+	wantOffset := [3]float64{0, -100, 50}
+	if got.HardOffset != wantOffset {
+		t.Errorf("HardOffset = %v, want %v", got.HardOffset, wantOffset)
+	}
+
+	// radii are 100, 100, 100 -> avgRadius 100 -> scale 1 on every axis
+	for i := 0; i < 3; i++ {
+		if !floatsClose(got.SoftScale[i][i], 1) {
+			t.Errorf("SoftScale[%d][%d] = %v, want 1", i, i, got.SoftScale[i][i])
+		}
+	}
+}
+
+func TestDeriveQMC5883LCalibrationZeroRadius(t *testing.T) {
+	min := [3]float64{0, -10, -10}
+	max := [3]float64{0, 10, 10}
+
+	got := deriveQMC5883LCalibration(min, max)
+
+	if !floatsClose(got.SoftScale[0][0], 1) {
+		t.Errorf("SoftScale[0][0] = %v, want 1 for a zero-radius axis", got.SoftScale[0][0])
+	}
+}
+
+func TestQMC5883LNormalizeHeading(t *testing.T) {
+	cases := []struct {
+		radians     float64
+		declination float64
+		want        float64
+	}{
+		{0, 0, 0},
+		{math.Pi, 0, 180},
+		{-math.Pi / 2, 0, 270},
+		{0, 350, 350},
+		{0, 370, 10},
+	}
+
+	for _, c := range cases {
+		q := &QMC5883LDriver{declination: c.declination}
+		got := q.normalizeHeading(c.radians)
+		if !floatsClose(got, c.want) {
+			t.Errorf("normalizeHeading(%v) with declination %v = %v, want %v", c.radians, c.declination, got, c.want)
+		}
+	}
+}
+
+func TestQMC5883LTiltCompensatedHeadingFromSampleLevel(t *testing.T) {
+	q := &QMC5883LDriver{}
+
+	// With zero pitch/roll, tilt compensation should match the uncompensated formula.
+	got := q.tiltCompensatedHeadingFromSample(1, 1, 0, 0, 0)
+	want := q.normalizeHeading(math.Atan2(-1, 1))
+
+	if !floatsClose(got, want) {
+		t.Errorf("tiltCompensatedHeadingFromSample at level = %v, want %v", got, want)
+	}
+}
+
+func TestQMC5883LApplyLowPass(t *testing.T) {
+	q := &QMC5883LDriver{lowPassAlpha: 0.5}
+
+	x, y, z := q.applyLowPass(10, 20, 30)
+	if !floatsClose(x, 10) || !floatsClose(y, 20) || !floatsClose(z, 30) {
+		t.Errorf("first applyLowPass call = (%v, %v, %v), want (10, 20, 30)", x, y, z)
+	}
+
+	x, y, z = q.applyLowPass(20, 40, 60)
+	if !floatsClose(x, 15) || !floatsClose(y, 30) || !floatsClose(z, 45) {
+		t.Errorf("second applyLowPass call = (%v, %v, %v), want (15, 30, 45)", x, y, z)
+	}
+}
+
+func TestQMC5883LSetGetCalibration(t *testing.T) {
+	q := newTestQMC5883LDriver()
+
+	hard := [3]float64{1, 2, 3}
+	soft := [3][3]float64{{2, 0, 0}, {0, 3, 0}, {0, 0, 4}}
+
+	q.SetCalibration(hard, soft)
+
+	gotHard, gotSoft := q.GetCalibration()
+	if gotHard != hard {
+		t.Errorf("GetCalibration hard offset = %v, want %v", gotHard, hard)
+	}
+	if gotSoft != soft {
+		t.Errorf("GetCalibration soft scale = %v, want %v", gotSoft, soft)
+	}
+}
+
+func TestQMC5883LSaveLoadCalibration(t *testing.T) {
+	q := newTestQMC5883LDriver()
+	q.SetCalibration([3]float64{1, 2, 3}, [3][3]float64{{2, 0, 0}, {0, 3, 0}, {0, 0, 4}})
+
+	var buf bytes.Buffer
+	if err := q.SaveCalibration(&buf); err != nil {
+		t.Fatalf("SaveCalibration failed: %v", err)
+	}
+
+	loaded := newTestQMC5883LDriver()
+	if err := loaded.LoadCalibration(&buf); err != nil {
+		t.Fatalf("LoadCalibration failed: %v", err)
+	}
+
+	wantHard, wantSoft := q.GetCalibration()
+	gotHard, gotSoft := loaded.GetCalibration()
+	if gotHard != wantHard {
+		t.Errorf("loaded hard offset = %v, want %v", gotHard, wantHard)
+	}
+	if gotSoft != wantSoft {
+		t.Errorf("loaded soft scale = %v, want %v", gotSoft, wantSoft)
+	}
+}